@@ -7,18 +7,24 @@ package veritas
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha1"
 	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
-	"net"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -46,7 +52,28 @@ const (
 	VALTYPE_COUNT = 2
 )
 
-var requestTimeout = time.Duration(10 * time.Second)
+// defaultRequestTimeout bounds calls made through the non-Context API, where
+// the caller has no context.Context of their own to set a deadline on.
+const defaultRequestTimeout = 10 * time.Second
+
+// RetryPolicy controls how ExecuteContext retries a request. Backoff
+// doubles each attempt starting from InitialBackoff, capped at MaxBackoff,
+// with full jitter applied to avoid thundering-herd retries.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// defaultRetryPolicy is used whenever a VeritasClient hasn't been given one
+// via SetRetryPolicy.
+func defaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+	}
+}
 
 func NewClient(customerId int, applicationId int, secureToken string) *VeritasClient {
 	obj := &VeritasClient{
@@ -54,6 +81,33 @@ func NewClient(customerId int, applicationId int, secureToken string) *VeritasCl
 		applicationId: applicationId,
 		secureToken:   secureToken,
 		logLevel:      LOG_WARN,
+		retryPolicy:   defaultRetryPolicy(),
+		nonceSource:   &monotonicNonceSource{},
+	}
+	obj.SetVersion(API_VERSION)
+	obj.SetEndpoint(API_ENDPOINT)
+	obj.SetRegion(REGION_ANY)
+	return obj
+}
+
+// NewClientTLS constructs a VeritasClient authenticated via a client TLS
+// certificate instead of the shared secureToken. caPool validates the
+// server's certificate chain and cert identifies this client; the server
+// reads the customer/application from the certificate's SAN/CN, so request
+// signing via signRequest is skipped in favor of TLS-layer auth. Because
+// auth now lives in the handshake, the endpoint is forced to https.
+func NewClientTLS(customerId int, applicationId int, caPool *x509.CertPool, cert tls.Certificate) *VeritasClient {
+	obj := &VeritasClient{
+		customerId:    customerId,
+		applicationId: applicationId,
+		logLevel:      LOG_WARN,
+		certAuth:      true,
+		retryPolicy:   defaultRetryPolicy(),
+		nonceSource:   &monotonicNonceSource{},
+		tlsConfig: &tls.Config{
+			RootCAs:      caPool,
+			Certificates: []tls.Certificate{cert},
+		},
 	}
 	obj.SetVersion(API_VERSION)
 	obj.SetEndpoint(API_ENDPOINT)
@@ -74,9 +128,67 @@ func (v *VeritasClient) SetRegion(region string) {
 }
 
 func (v *VeritasClient) SetEndpoint(endpoint string) {
+	// TLS client-cert auth establishes identity in the handshake, so the
+	// endpoint must be https even if a caller passes the http default.
+	if v.certAuth && strings.HasPrefix(endpoint, "http://") {
+		endpoint = "https://" + strings.TrimPrefix(endpoint, "http://")
+	}
 	v.endpoint = endpoint
 }
 
+// SetHTTPClient overrides the *http.Client requests execute on, e.g. to
+// reuse a client shared across multiple Veritas clients or to install a
+// custom transport. It takes effect on the next request.
+//
+// If this client was built with NewClientTLS, the client-cert TLS config is
+// reapplied onto the given client's transport, so swapping in a plain
+// http.Client can never silently drop mTLS.
+func (v *VeritasClient) SetHTTPClient(client *http.Client) {
+	if v.tlsConfig != nil {
+		client.Transport = withTLSConfig(client.Transport, v.tlsConfig)
+	}
+	v.httpClientMu.Lock()
+	v.httpClient = client
+	v.httpClientMu.Unlock()
+}
+
+// withTLSConfig returns a RoundTripper equivalent to rt with tlsConfig set as
+// its TLSClientConfig, replacing any TLSClientConfig rt already had. It
+// clones an *http.Transport rather than mutating the
+// caller's value. rt must be an *http.Transport or nil; anything else can't
+// be safely reconfigured, so this panics rather than silently dropping the
+// client certificate.
+func withTLSConfig(rt http.RoundTripper, tlsConfig *tls.Config) http.RoundTripper {
+	var transport *http.Transport
+	switch t := rt.(type) {
+	case nil:
+		transport = &http.Transport{}
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		panic("veritas: SetHTTPClient on a TLS client-cert client requires an *http.Transport (or nil Transport) so the client certificate can be applied")
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport
+}
+
+// SetRetryPolicy overrides the default RetryPolicy applied by
+// ExecuteContext. Safe to call concurrently with in-flight requests, e.g.
+// from a worker pool sharing one VeritasClient.
+func (v *VeritasClient) SetRetryPolicy(policy *RetryPolicy) {
+	v.configMu.Lock()
+	v.retryPolicy = policy
+	v.configMu.Unlock()
+}
+
+// retryPolicyFor returns the client's current RetryPolicy, guarded against
+// a concurrent SetRetryPolicy the same way httpClientFor guards httpClient.
+func (v *VeritasClient) retryPolicyFor() *RetryPolicy {
+	v.configMu.RLock()
+	defer v.configMu.RUnlock()
+	return v.retryPolicy
+}
+
 func (v *VeritasClient) SetLogLevel(l int) bool {
 	if l < LOG_ERROR || l > LOG_TRACE {
 		log.Println("Invalid log level, ignoring update")
@@ -92,6 +204,12 @@ func (v *VeritasClient) PrintDebug() {
 
 // Get multi
 func (v *VeritasClient) GetMulti(table string, keymap map[string][]string) (*Response, error) {
+	return v.GetMultiContext(context.Background(), table, keymap)
+}
+
+// Get multi, bound to ctx: the in-flight HTTP request is canceled when ctx is
+// canceled or its deadline elapses.
+func (v *VeritasClient) GetMultiContext(ctx context.Context, table string, keymap map[string][]string) (*Response, error) {
 	// Create object
 	outer := NewRequestPayload()
 	outer.DefaultDb = v.database
@@ -116,12 +234,35 @@ func (v *VeritasClient) GetMulti(table string, keymap map[string][]string) (*Res
 	urlData := v.encodeUri(string(jsonBytes))
 
 	r := v.newRequest(v, "GET", fmt.Sprintf("data-multi/%s", urlData), VALTYPE_DATA, RESPONSETYPE_FETCH_MULTI)
-	res, resErr := r.Execute()
+	res, resErr := r.ExecuteContext(ctx)
 	return res, resErr
 }
 
+// GetMultiTyped is GetMulti decoded straight into T via Decode, instead of
+// the stringly-typed map DataMapValues returns. It's a free function, not a
+// method, because Go methods can't carry their own type parameters.
+func GetMultiTyped[T any](v *VeritasClient, table string, keymap map[string][]string) (map[string]map[string]T, error) {
+	return GetMultiTypedContext[T](context.Background(), v, table, keymap)
+}
+
+// GetMultiTypedContext is GetMultiContext decoded straight into T via
+// Decode.
+func GetMultiTypedContext[T any](ctx context.Context, v *VeritasClient, table string, keymap map[string][]string) (map[string]map[string]T, error) {
+	resp, err := v.GetMultiContext(ctx, table, keymap)
+	if err != nil {
+		return nil, err
+	}
+	return Decode[T](resp)
+}
+
 // Put multi
 func (v *VeritasClient) PutMulti(table string, keymap map[string]map[string]string) (*Response, error) {
+	return v.PutMultiContext(context.Background(), table, keymap)
+}
+
+// Put multi, bound to ctx: the in-flight HTTP request is canceled when ctx is
+// canceled or its deadline elapses.
+func (v *VeritasClient) PutMultiContext(ctx context.Context, table string, keymap map[string]map[string]string) (*Response, error) {
 	// Create object
 	outer := NewRequestPayload()
 	outer.DefaultDb = v.database
@@ -148,12 +289,18 @@ func (v *VeritasClient) PutMulti(table string, keymap map[string]map[string]stri
 	r := v.newRequest(v, "PUT", "data", VALTYPE_DATA, RESPONSETYPE_MUTATION)
 	r.body = string(jsonBytes)
 	r.mutations = mutationCount
-	res, resErr := r.Execute()
+	res, resErr := r.ExecuteContext(ctx)
 	return res, resErr
 }
 
 // Delete multi
 func (v *VeritasClient) DeleteMulti(table string, keymap map[string][]string) (*Response, error) {
+	return v.DeleteMultiContext(context.Background(), table, keymap)
+}
+
+// Delete multi, bound to ctx: the in-flight HTTP request is canceled when ctx
+// is canceled or its deadline elapses.
+func (v *VeritasClient) DeleteMultiContext(ctx context.Context, table string, keymap map[string][]string) (*Response, error) {
 	// Create object
 	outer := NewRequestPayload()
 	outer.DefaultDb = v.database
@@ -180,12 +327,18 @@ func (v *VeritasClient) DeleteMulti(table string, keymap map[string][]string) (*
 	r := v.newRequest(v, "DELETE", "data", VALTYPE_DATA, RESPONSETYPE_MUTATION)
 	r.body = string(jsonBytes)
 	r.mutations = mutationCount
-	res, resErr := r.Execute()
+	res, resErr := r.ExecuteContext(ctx)
 	return res, resErr
 }
 
 // Get multi counti
 func (v *VeritasClient) GetMultiCount(table string, keymap map[string][]string) (*Response, error) {
+	return v.GetMultiCountContext(context.Background(), table, keymap)
+}
+
+// Get multi count, bound to ctx: the in-flight HTTP request is canceled when
+// ctx is canceled or its deadline elapses.
+func (v *VeritasClient) GetMultiCountContext(ctx context.Context, table string, keymap map[string][]string) (*Response, error) {
 	// Create object
 	outer := NewRequestPayload()
 	outer.DefaultDb = v.database
@@ -210,12 +363,34 @@ func (v *VeritasClient) GetMultiCount(table string, keymap map[string][]string)
 	urlData := v.encodeUri(string(jsonBytes))
 
 	r := v.newRequest(v, "GET", fmt.Sprintf("count-multi/%s", urlData), VALTYPE_COUNT, RESPONSETYPE_FETCH_MULTI)
-	res, resErr := r.Execute()
+	res, resErr := r.ExecuteContext(ctx)
 	return res, resErr
 }
 
+// GetMultiCountTyped is GetMultiCount decoded straight into T via Decode,
+// instead of the int64-only map DataCountValues returns.
+func GetMultiCountTyped[T any](v *VeritasClient, table string, keymap map[string][]string) (map[string]map[string]T, error) {
+	return GetMultiCountTypedContext[T](context.Background(), v, table, keymap)
+}
+
+// GetMultiCountTypedContext is GetMultiCountContext decoded straight into T
+// via Decode.
+func GetMultiCountTypedContext[T any](ctx context.Context, v *VeritasClient, table string, keymap map[string][]string) (map[string]map[string]T, error) {
+	resp, err := v.GetMultiCountContext(ctx, table, keymap)
+	if err != nil {
+		return nil, err
+	}
+	return Decode[T](resp)
+}
+
 // Put multi count
 func (v *VeritasClient) PutMultiCount(table string, keymap map[string]map[string]int64) (*Response, error) {
+	return v.PutMultiCountContext(context.Background(), table, keymap)
+}
+
+// Put multi count, bound to ctx: the in-flight HTTP request is canceled when
+// ctx is canceled or its deadline elapses.
+func (v *VeritasClient) PutMultiCountContext(ctx context.Context, table string, keymap map[string]map[string]int64) (*Response, error) {
 	// Create object
 	outer := NewRequestPayload()
 	outer.DefaultDb = v.database
@@ -242,12 +417,18 @@ func (v *VeritasClient) PutMultiCount(table string, keymap map[string]map[string
 	r := v.newRequest(v, "PUT", "count", VALTYPE_COUNT, RESPONSETYPE_MUTATION)
 	r.body = string(jsonBytes)
 	r.mutations = mutationCount
-	res, resErr := r.Execute()
+	res, resErr := r.ExecuteContext(ctx)
 	return res, resErr
 }
 
 // Delete multi counti
 func (v *VeritasClient) DeleteMultiCount(table string, keymap map[string][]string) (*Response, error) {
+	return v.DeleteMultiCountContext(context.Background(), table, keymap)
+}
+
+// Delete multi count, bound to ctx: the in-flight HTTP request is canceled
+// when ctx is canceled or its deadline elapses.
+func (v *VeritasClient) DeleteMultiCountContext(ctx context.Context, table string, keymap map[string][]string) (*Response, error) {
 	// Create object
 	outer := NewRequestPayload()
 	outer.DefaultDb = v.database
@@ -274,7 +455,7 @@ func (v *VeritasClient) DeleteMultiCount(table string, keymap map[string][]strin
 	r := v.newRequest(v, "DELETE", "count", VALTYPE_COUNT, RESPONSETYPE_MUTATION)
 	r.body = string(jsonBytes)
 	r.mutations = mutationCount
-	res, resErr := r.Execute()
+	res, resErr := r.ExecuteContext(ctx)
 	return res, resErr
 }
 
@@ -289,13 +470,25 @@ func (v *VeritasClient) encodeUri(str string) string {
 
 // Get single
 func (v *VeritasClient) GetSingle(table string, key string, subkey string) (*Response, error) {
+	return v.GetSingleContext(context.Background(), table, key, subkey)
+}
+
+// Get single, bound to ctx: the in-flight HTTP request is canceled when ctx
+// is canceled or its deadline elapses.
+func (v *VeritasClient) GetSingleContext(ctx context.Context, table string, key string, subkey string) (*Response, error) {
 	r := v.newRequest(v, "GET", fmt.Sprintf("data/%s/%s/%s/%s", v.database, table, key, subkey), VALTYPE_DATA, RESPONSETYPE_FETCH_SINGLE)
-	res, resErr := r.Execute()
+	res, resErr := r.ExecuteContext(ctx)
 	return res, resErr
 }
 
 // Put single
 func (v *VeritasClient) PutSingle(table string, key string, subkey string, value string) (*Response, error) {
+	return v.PutSingleContext(context.Background(), table, key, subkey, value)
+}
+
+// Put single, bound to ctx: the in-flight HTTP request is canceled when ctx
+// is canceled or its deadline elapses.
+func (v *VeritasClient) PutSingleContext(ctx context.Context, table string, key string, subkey string, value string) (*Response, error) {
 	r := v.newRequest(v, "PUT", "data", VALTYPE_DATA, RESPONSETYPE_MUTATION)
 
 	// Create object
@@ -320,19 +513,111 @@ func (v *VeritasClient) PutSingle(table string, key string, subkey string, value
 
 	r.body = string(bodyBytes)
 	r.mutations = 1
-	res, resErr := r.Execute()
+	res, resErr := r.ExecuteContext(ctx)
+	return res, resErr
+}
+
+// PutSingleIdempotent is PutSingle with an idempotency key, which opts the
+// mutation into ExecuteContext's automatic retries on network errors, 5xx,
+// and 429 responses.
+func (v *VeritasClient) PutSingleIdempotent(table string, key string, subkey string, value string, idempotencyKey string) (*Response, error) {
+	return v.PutSingleIdempotentContext(context.Background(), table, key, subkey, value, idempotencyKey)
+}
+
+// PutSingleIdempotentContext is PutSingleContext with an idempotency key,
+// which opts the mutation into ExecuteContext's automatic retries on
+// network errors, 5xx, and 429 responses.
+func (v *VeritasClient) PutSingleIdempotentContext(ctx context.Context, table string, key string, subkey string, value string, idempotencyKey string) (*Response, error) {
+	r := v.newRequest(v, "PUT", "data", VALTYPE_DATA, RESPONSETYPE_MUTATION)
+
+	// Create object
+	outer := NewRequestPayload()
+	outer.DefaultDb = v.database
+	outer.DefaultTable = table
+
+	// One object
+	object := NewPayloadObjectsKeyValues()
+	object.Key = key
+	object.Values[subkey] = value
+	object.IdempotencyKey = idempotencyKey
+	outer.Objects = append(outer.Objects, object)
+
+	// To json
+	bodyBytes, jsonErr := json.Marshal(outer)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if v.logLevel >= LOG_TRACE {
+		log.Println(string(bodyBytes))
+	}
+
+	r.body = string(bodyBytes)
+	r.mutations = 1
+	r.idempotent = idempotencyKey != ""
+	res, resErr := r.ExecuteContext(ctx)
 	return res, resErr
 }
 
 // Get single count
 func (v *VeritasClient) GetSingleCount(table string, key string, subkey string) (*Response, error) {
+	return v.GetSingleCountContext(context.Background(), table, key, subkey)
+}
+
+// Get single count, bound to ctx: the in-flight HTTP request is canceled
+// when ctx is canceled or its deadline elapses.
+func (v *VeritasClient) GetSingleCountContext(ctx context.Context, table string, key string, subkey string) (*Response, error) {
 	r := v.newRequest(v, "GET", fmt.Sprintf("count/%s/%s/%s/%s", v.database, table, key, subkey), VALTYPE_COUNT, RESPONSETYPE_FETCH_SINGLE)
-	res, resErr := r.Execute()
+	res, resErr := r.ExecuteContext(ctx)
 	return res, resErr
 }
 
 // Increment single count
 func (v *VeritasClient) IncrementSingleCount(table string, key string, subkey string, value int) (*Response, error) {
+	return v.IncrementSingleCountContext(context.Background(), table, key, subkey, value)
+}
+
+// Increment single count, bound to ctx: the in-flight HTTP request is
+// canceled when ctx is canceled or its deadline elapses.
+func (v *VeritasClient) IncrementSingleCountContext(ctx context.Context, table string, key string, subkey string, value int) (*Response, error) {
+	r := v.newRequest(v, "PUT", "count", VALTYPE_COUNT, RESPONSETYPE_MUTATION)
+
+	// Create object
+	outer := NewRequestPayload()
+	outer.DefaultDb = v.database
+	outer.DefaultTable = table
+
+	// One object
+	object := NewPayloadObjectsKeyValues()
+	object.Key = key
+	object.Values[subkey] = value
+	outer.Objects = append(outer.Objects, object)
+
+	// To json
+	bodyBytes, jsonErr := json.Marshal(outer)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if v.logLevel >= LOG_TRACE {
+		log.Println(string(bodyBytes))
+	}
+
+	r.body = string(bodyBytes)
+	r.mutations = 1
+	res, resErr := r.ExecuteContext(ctx)
+	return res, resErr
+}
+
+// IncrementSingleCountIdempotent is IncrementSingleCount with an
+// idempotency key, which opts the mutation into ExecuteContext's automatic
+// retries on network errors, 5xx, and 429 responses.
+func (v *VeritasClient) IncrementSingleCountIdempotent(table string, key string, subkey string, value int, idempotencyKey string) (*Response, error) {
+	return v.IncrementSingleCountIdempotentContext(context.Background(), table, key, subkey, value, idempotencyKey)
+}
+
+// IncrementSingleCountIdempotentContext is IncrementSingleCountContext with
+// an idempotency key, which opts the mutation into ExecuteContext's
+// automatic retries on network errors, 5xx, and 429 responses.
+func (v *VeritasClient) IncrementSingleCountIdempotentContext(ctx context.Context, table string, key string, subkey string, value int, idempotencyKey string) (*Response, error) {
 	r := v.newRequest(v, "PUT", "count", VALTYPE_COUNT, RESPONSETYPE_MUTATION)
 
 	// Create object
@@ -344,6 +629,7 @@ func (v *VeritasClient) IncrementSingleCount(table string, key string, subkey st
 	object := NewPayloadObjectsKeyValues()
 	object.Key = key
 	object.Values[subkey] = value
+	object.IdempotencyKey = idempotencyKey
 	outer.Objects = append(outer.Objects, object)
 
 	// To json
@@ -357,49 +643,218 @@ func (v *VeritasClient) IncrementSingleCount(table string, key string, subkey st
 
 	r.body = string(bodyBytes)
 	r.mutations = 1
-	res, resErr := r.Execute()
+	r.idempotent = idempotencyKey != ""
+	res, resErr := r.ExecuteContext(ctx)
 	return res, resErr
 }
 
-// Sign a request
-func (r *Request) signRequest() string {
+// SetClock overrides the source of the current time used to stamp the
+// X-Veritas-Timestamp header and fold into the request signature. Intended
+// for tests; production callers should leave this unset so time.Now is used.
+func (v *VeritasClient) SetClock(clock func() time.Time) {
+	v.configMu.Lock()
+	v.clock = clock
+	v.configMu.Unlock()
+}
+
+// SetNonceSource overrides the source of per-request nonce bytes folded
+// into the request signature and emitted as X-Veritas-Nonce. Intended for
+// tests that need a deterministic nonce; production callers should leave
+// this unset so the default monotonic counter is used.
+func (v *VeritasClient) SetNonceSource(source io.Reader) {
+	v.configMu.Lock()
+	v.nonceSource = source
+	v.configMu.Unlock()
+}
+
+// now returns the client's current time, honoring an overridden clock.
+func (v *VeritasClient) now() time.Time {
+	v.configMu.RLock()
+	clock := v.clock
+	v.configMu.RUnlock()
+	if clock != nil {
+		return clock()
+	}
+	return time.Now()
+}
+
+// nextNonce draws 8 bytes from the client's nonce source, hex-encoded. The
+// default source is a monotonic counter, so nonces strictly increase for
+// the lifetime of the client; a server can therefore reject any nonce it
+// has already seen within its acceptance window.
+func (v *VeritasClient) nextNonce() string {
+	v.configMu.RLock()
+	source := v.nonceSource
+	v.configMu.RUnlock()
+	if source == nil {
+		source = defaultNonceSource
+	}
+	buf := make([]byte, 8)
+	if _, err := io.ReadFull(source, buf); err != nil {
+		// A custom source that can't supply 8 bytes shouldn't block the
+		// request; fall back to the clock, which is still unique enough in
+		// practice.
+		binary.BigEndian.PutUint64(buf, uint64(v.now().UnixNano()))
+	}
+	return fmt.Sprintf("%x", buf)
+}
+
+// monotonicNonceSource is the default nonce source: each Read hands back the
+// next value of an atomic counter, so nonces minted by one client are
+// always strictly increasing.
+type monotonicNonceSource struct {
+	counter uint64
+}
+
+func (m *monotonicNonceSource) Read(p []byte) (int, error) {
+	n := atomic.AddUint64(&m.counter, 1)
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], n)
+	return copy(p, buf[:]), nil
+}
+
+// defaultNonceSource backstops clients that bypass NewClient/NewClientTLS
+// (and so never got their own monotonicNonceSource).
+var defaultNonceSource = &monotonicNonceSource{}
+
+// signRequest computes the signature for a request minted right now: a
+// fresh nonce plus the client's current time. doOnce does not call it, since
+// it needs the nonce and timestamp for its headers too and so calls
+// signRequestWith; tests with an injected clock/nonce source use this.
+func (v *VeritasClient) signRequest(method, url, body string) string {
+	return v.signRequestWith(method, url, body, v.nextNonce(), formatTimestamp(v.now()))
+}
+
+// signRequestWith computes the SHA-512 signature over method, url, the
+// shared secret, the body length, a SHA-1 digest of the body, the nonce,
+// and the timestamp, in that fixed order. nonce and timestamp are passed in
+// explicitly (rather than minted here) so doOnce can sign and set the
+// matching X-Veritas-Nonce/X-Veritas-Timestamp headers from the same
+// values.
+func (v *VeritasClient) signRequestWith(method, url, body, nonce, timestamp string) string {
 	// Sha512 hasher
 	hasher := sha512.New()
 
 	// Method
-	io.WriteString(hasher, r.method)
+	io.WriteString(hasher, method)
 
 	// Url
-	io.WriteString(hasher, r.getUrl())
+	io.WriteString(hasher, url)
 
 	// Token
-	io.WriteString(hasher, r.client.secureToken)
+	io.WriteString(hasher, v.secureToken)
 
 	// Content length
-	io.WriteString(hasher, fmt.Sprintf("%d", len(r.body)))
+	io.WriteString(hasher, fmt.Sprintf("%d", len(body)))
 
 	// Content hash
 	sha1H := sha1.New()
-	io.WriteString(sha1H, r.body)
+	io.WriteString(sha1H, body)
 	sha1Body := fmt.Sprintf("%x", sha1H.Sum(nil))
 	io.WriteString(hasher, sha1Body)
 
+	// Nonce
+	io.WriteString(hasher, nonce)
+
+	// Timestamp
+	io.WriteString(hasher, timestamp)
+
 	// Done
 	return fmt.Sprintf("%x", hasher.Sum(nil))
 }
 
+// formatTimestamp renders t as the Unix-seconds string carried in the
+// X-Veritas-Timestamp header and folded into the signature.
+func formatTimestamp(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
 // Get url
 func (r *Request) getUrl() string {
 	return fmt.Sprintf("/%s/%s", r.client.version, r.endpoint)
 }
 
-// Request timeout helper
-func dialTimeout(network, addr string) (net.Conn, error) {
-	return net.DialTimeout(network, addr, requestTimeout)
+// Execute request. Execute is context-free for callers that can't supply
+// one; it runs under defaultRequestTimeout. Prefer ExecuteContext (reached
+// through the *Context client methods) so cancellation propagates from the
+// caller instead.
+func (r *Request) Execute() (*Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	defer cancel()
+	return r.ExecuteContext(ctx)
 }
 
-// Execute request
-func (r *Request) Execute() (*Response, error) {
+// ExecuteContext runs the request with ctx governing its deadline and
+// cancellation. If ctx carries no deadline, defaultRequestTimeout is applied
+// so requests can't hang indefinitely. The in-flight HTTP request is
+// canceled as soon as ctx is done.
+//
+// Network errors, 5xx, and 429 responses are retried under the client's
+// RetryPolicy, but only when the request is safe to resend: GET requests
+// always are, mutation requests only if canRetry() says so (see
+// PutSingleIdempotent and friends).
+func (r *Request) ExecuteContext(ctx context.Context) (*Response, error) {
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultRequestTimeout)
+		defer cancel()
+	}
+
+	policy := r.client.retryPolicyFor()
+	if policy == nil || policy.MaxAttempts < 1 {
+		// A policy left at its zero value (MaxAttempts == 0) would otherwise
+		// make the loop below execute zero times and return (nil, nil).
+		policy = defaultRetryPolicy()
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < policy.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			if err := backoffSleep(ctx, policy, attempt); err != nil {
+				return nil, err
+			}
+		}
+
+		res, status, err := r.doOnce(ctx)
+		if err != nil {
+			lastErr = err
+			if r.canRetry() && attempt < policy.MaxAttempts-1 {
+				continue
+			}
+			return nil, err
+		}
+		if isRetryableStatus(status) {
+			lastErr = fmt.Errorf("veritas: retryable response status %d", status)
+			if r.canRetry() && attempt < policy.MaxAttempts-1 {
+				continue
+			}
+			// Retries exhausted (or not safe to retry) and the status is
+			// still a failure: surface lastErr instead of falling through
+			// to a success return, mirroring the transport-error path above.
+			return nil, lastErr
+		}
+		return res, nil
+	}
+	return nil, lastErr
+}
+
+// canRetry reports whether r is safe to resend. GET requests are always
+// idempotent; mutation requests must have opted in via an idempotency key
+// (see PutSingleIdempotent and friends) before a retry will fire.
+func (r *Request) canRetry() bool {
+	return r.method == "GET" || r.idempotent
+}
+
+// isRetryableStatus reports whether an HTTP status is worth retrying:
+// network-layer failures aside, only 5xx and 429 responses are transient.
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// doOnce performs a single HTTP round trip and returns the parsed response
+// alongside the raw HTTP status code, which ExecuteContext needs to decide
+// whether to retry.
+func (r *Request) doOnce(ctx context.Context) (*Response, int, error) {
 	// Url
 	fullUrl := fmt.Sprintf("%s%s", r.client.endpoint, r.getUrl())
 	if r.client.logLevel >= LOG_TRACE {
@@ -407,14 +862,25 @@ func (r *Request) Execute() (*Response, error) {
 	}
 
 	// Create request
-	req, reqErr := http.NewRequest(r.method, fullUrl, bytes.NewBuffer([]byte(r.body)))
+	req, reqErr := http.NewRequestWithContext(ctx, r.method, fullUrl, bytes.NewBuffer([]byte(r.body)))
 	if reqErr != nil {
-		return nil, reqErr
+		return nil, 0, reqErr
 	}
 
-	// Auth token in header
-	signature := r.signRequest()
-	req.Header.Set("X-Auth", signature)
+	// Auth token in header. Client-cert auth establishes identity at the TLS
+	// layer, so the shared-secret signature is skipped — but only once
+	// certAuthActive confirms the active transport actually carries the
+	// cert, so the request is never sent unauthenticated by both measures.
+	// Nonce and timestamp are minted once and reused for both the signature
+	// and their headers, so the server can recompute the same digest.
+	if !r.client.certAuthActive() {
+		nonce := r.client.nextNonce()
+		timestamp := formatTimestamp(r.client.now())
+		signature := r.client.signRequestWith(r.method, r.getUrl(), r.body, nonce, timestamp)
+		req.Header.Set("X-Auth", signature)
+		req.Header.Set("X-Veritas-Nonce", nonce)
+		req.Header.Set("X-Veritas-Timestamp", timestamp)
+	}
 
 	// Content type
 	if len(r.body) > 0 {
@@ -424,27 +890,17 @@ func (r *Request) Execute() (*Response, error) {
 	// Route header
 	req.Header.Set("X-Veritas-Route", fmt.Sprintf("%s/%d/%d", r.client.region, r.client.applicationId, r.client.customerId))
 
-	// HTTP transport
-	transport := http.Transport{
-		Dial: dialTimeout,
-	}
-
-	// HTTP client
-	client := &http.Client{
-		Transport: &transport,
-	}
-
 	// Execute
-	resp, err := client.Do(req)
+	resp, err := r.client.httpClientFor().Do(req)
 	if err != nil {
-		panic(err)
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
 	// Read body
 	body, bodyErr := ioutil.ReadAll(resp.Body)
 	if bodyErr != nil {
-		return nil, bodyErr
+		return nil, resp.StatusCode, bodyErr
 	}
 	bodyStr := string(body)
 
@@ -459,7 +915,31 @@ func (r *Request) Execute() (*Response, error) {
 	res := NewResponse(r, bodyStr)
 
 	// Return
-	return res, nil
+	return res, resp.StatusCode, nil
+}
+
+// backoffSleep waits out the jittered exponential backoff before retry
+// attempt n (n >= 1), returning early with ctx.Err() if ctx is done first.
+// A policy with no backoff configured (InitialBackoff and MaxBackoff both
+// zero) is a valid way to ask for "retry immediately" and returns at once.
+func backoffSleep(ctx context.Context, policy *RetryPolicy, attempt int) error {
+	backoff := policy.InitialBackoff << uint(attempt-1)
+	if backoff <= 0 || backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+	if backoff <= 0 {
+		return nil
+	}
+	jittered := time.Duration(rand.Int63n(int64(backoff)))
+
+	timer := time.NewTimer(jittered)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // New request
@@ -483,6 +963,44 @@ func (v *VeritasClient) newRequestOpts() *RequestOpts {
 	}
 }
 
+// httpClientFor returns the *http.Client requests should execute on,
+// building it on first use so the transport (and its TLS client-cert
+// config, when NewClientTLS was used) is set up once instead of per call.
+// Guarded by httpClientMu so concurrent callers can't race on the lazy
+// build.
+func (v *VeritasClient) httpClientFor() *http.Client {
+	v.httpClientMu.Lock()
+	defer v.httpClientMu.Unlock()
+	if v.httpClient == nil {
+		transport := &http.Transport{
+			MaxIdleConnsPerHost: 16,
+			IdleConnTimeout:     90 * time.Second,
+			DisableKeepAlives:   false,
+		}
+		if v.tlsConfig != nil {
+			transport.TLSClientConfig = v.tlsConfig
+		}
+		v.httpClient = &http.Client{Transport: transport}
+	}
+	return v.httpClient
+}
+
+// certAuthActive reports whether this request should skip the HMAC
+// signature in favor of TLS client-cert auth. It isn't enough that certAuth
+// was set at construction: the active transport must actually carry a
+// client certificate, so a client whose http.Client was swapped out from
+// under tlsConfig never ends up sending neither a cert nor a signature.
+func (v *VeritasClient) certAuthActive() bool {
+	if !v.certAuth {
+		return false
+	}
+	transport, ok := v.httpClientFor().Transport.(*http.Transport)
+	if !ok || transport.TLSClientConfig == nil {
+		return false
+	}
+	return len(transport.TLSClientConfig.Certificates) > 0
+}
+
 type VeritasClient struct {
 	customerId    int
 	applicationId int
@@ -492,6 +1010,14 @@ type VeritasClient struct {
 	endpoint      string
 	region        string
 	logLevel      int
+	tlsConfig     *tls.Config
+	certAuth      bool
+	httpClient    *http.Client
+	httpClientMu  sync.Mutex
+	configMu      sync.RWMutex // guards retryPolicy, clock, nonceSource below
+	retryPolicy   *RetryPolicy
+	clock         func() time.Time
+	nonceSource   io.Reader
 }
 
 type RequestOpts struct {
@@ -508,7 +1034,8 @@ type Request struct {
 	opts         *RequestOpts
 	valType      int
 	responseType int
-	mutations    int // The amount of mutations we're going to make
+	mutations    int  // The amount of mutations we're going to make
+	idempotent   bool // Opted in via an idempotency key; safe for ExecuteContext to retry
 }
 
 // Payloads
@@ -526,10 +1053,11 @@ type RequestPayload struct {
 }
 
 type PayloadObjectsKeyValues struct {
-	Key           string                 `json:"k"`
-	DbOverride    string                 `json:"db_override,omitempty"`
-	TableOverride string                 `json:"table_override,omitempty"`
-	Values        map[string]interface{} `json:"v"`
+	Key            string                 `json:"k"`
+	DbOverride     string                 `json:"db_override,omitempty"`
+	TableOverride  string                 `json:"table_override,omitempty"`
+	Values         map[string]interface{} `json:"v"`
+	IdempotencyKey string                 `json:"idempotency_key,omitempty"` // Opts this mutation into ExecuteContext retries
 }
 
 type PayloadObjectsKeys struct {
@@ -560,6 +1088,18 @@ type Response struct {
 	Data          map[string]interface{}
 }
 
+// mutationEnvelope is the typed shape of a mutation response's "data"
+// object. Pointer fields distinguish "absent" from the zero value, which
+// parse uses the same way the old interface{}-keyed map did: acknowledged
+// governs success for async writes, executed for sync ones.
+type mutationEnvelope struct {
+	Data struct {
+		Mutations    *int64 `json:"mutations"`
+		Acknowledged *bool  `json:"acknowledged"`
+		Executed     *bool  `json:"executed"`
+	} `json:"data"`
+}
+
 func (r *Response) parse() {
 	// Valid body?
 	if len(r.RawBody) < 1 {
@@ -582,40 +1122,30 @@ func (r *Response) parse() {
 	r.Data = data
 
 	// Value extraction
-	if data["data"] != nil {
-		dataMap := data["data"].(map[string]interface{})
-		if r.Request.responseType == RESPONSETYPE_FETCH_SINGLE {
-			// Single value responses
-			if r.Request.valType == VALTYPE_DATA {
-				// One single value
-				for _, kv := range dataMap {
-					kvm := kv.(map[string]interface{})
-					for _, v := range kvm {
-						r.StrValue = fmt.Sprintf("%s", v)
-						break
-					}
-				}
-			} else if r.Request.valType == VALTYPE_COUNT {
-				// One single count
-				for _, kv := range dataMap {
-					kvm := kv.(map[string]interface{})
-					for _, v := range kvm {
-						f, fe := strconv.ParseFloat(fmt.Sprintf("%f", v), 64)
-						if fe == nil {
-							r.IntValue = int64(f)
-							break
-						}
-					}
-				}
-			}
-		} else if r.Request.responseType == RESPONSETYPE_MUTATION {
-			// Mutation count
-			var mutationCount int64 = -1
-			if dataMap["mutations"] != nil {
-				f, fe := strconv.ParseFloat(fmt.Sprintf("%f", dataMap["mutations"]), 64)
-				if fe == nil {
-					mutationCount = int64(f)
-				}
+	if data["data"] == nil {
+		return
+	}
+	if r.Request.responseType == RESPONSETYPE_FETCH_SINGLE {
+		// Single value responses, decoded straight into the wire type so
+		// counts above 2^53 survive intact and a non-string data value is
+		// reported as a decode error instead of being stringified. A decode
+		// error is kept on r.Error rather than discarded, so DataValue and
+		// CountValue can report it instead of always claiming success.
+		if r.Request.valType == VALTYPE_DATA {
+			m, decErr := Decode[string](r)
+			r.StrValue = firstValue(m)
+			r.Error = decErr
+		} else if r.Request.valType == VALTYPE_COUNT {
+			m, decErr := Decode[int64](r)
+			r.IntValue = firstValue(m)
+			r.Error = decErr
+		}
+	} else if r.Request.responseType == RESPONSETYPE_MUTATION {
+		var env mutationEnvelope
+		if decErr := json.Unmarshal([]byte(r.RawBody), &env); decErr == nil {
+			mutationCount := int64(-1)
+			if env.Data.Mutations != nil {
+				mutationCount = *env.Data.Mutations
 			}
 			r.MutationCount = mutationCount
 
@@ -628,80 +1158,129 @@ func (r *Response) parse() {
 			}
 
 			// Mutation parsing for success
-			if dataMap["acknowledged"] != nil {
+			if env.Data.Acknowledged != nil {
 				// Ack on async
-				r.Success = dataMap["acknowledged"].(bool)
-			} else if dataMap["executed"] != nil {
+				r.Success = *env.Data.Acknowledged
+			} else if env.Data.Executed != nil {
 				// Exec on sync
-				r.Success = dataMap["executed"].(bool)
+				r.Success = *env.Data.Executed
 			}
 		}
 	}
 }
 
-func (r *Response) DataValue() string {
-	if r.Request.responseType != RESPONSETYPE_FETCH_SINGLE || r.Request.valType != VALTYPE_DATA {
-		log.Fatal("Can not get data value from non-data response")
+// firstValue returns an arbitrary single value out of a key/subkey decode
+// result, for the single-fetch responses where exactly one is expected.
+func firstValue[T any](m map[string]map[string]T) T {
+	for _, kv := range m {
+		for _, v := range kv {
+			return v
+		}
 	}
-	return r.StrValue
+	var zero T
+	return zero
 }
 
-func (r *Response) DataMapValues() map[string]map[string]string {
-	if r.Request.responseType != RESPONSETYPE_FETCH_MULTI || r.Request.valType != VALTYPE_DATA {
-		log.Fatal("Can not get data map values from non-data response")
-	}
-	m := make(map[string]map[string]string)
-	if r.Data["data"] == nil {
-		return m
+// rawDecodedBody is the typed shape of a Veritas data response: values keyed
+// by object key then subkey, with each subkey left as a json.RawMessage so
+// Decode can decode entries into T independently instead of failing the
+// whole body on the first mismatched one.
+type rawDecodedBody struct {
+	Status string                                `json:"status"`
+	Data   map[string]map[string]json.RawMessage `json:"data"`
+}
+
+// Decode unmarshals resp.RawBody directly into T-typed values, replacing
+// the fmt.Sprintf("%s", v)/strconv.ParseFloat round-trip DataMapValues and
+// DataCountValues used to go through, which mangled non-string data and
+// silently lost precision for counts above 2^53. json.Number is enabled so
+// a T of interface{} still gets an exact textual number rather than a
+// lossy float64.
+//
+// Entries are decoded into T one at a time: a subkey whose value doesn't fit
+// T is skipped rather than discarding every other entry in the body, and the
+// first such error is returned alongside the partial result so callers can
+// decide whether a partial decode is acceptable.
+func Decode[T any](resp *Response) (map[string]map[string]T, error) {
+	var raw rawDecodedBody
+	dec := json.NewDecoder(strings.NewReader(resp.RawBody))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
 	}
-	if mi, ok := r.Data["data"].(map[string]interface{}); ok {
-		for k, miv := range mi {
-			if m[k] == nil {
-				m[k] = make(map[string]string)
-			}
-			if miva, ok := miv.(map[string]interface{}); ok {
-				for sk, sv := range miva {
-					m[k][sk] = fmt.Sprintf("%s", sv)
+
+	result := make(map[string]map[string]T, len(raw.Data))
+	var firstErr error
+	for key, sub := range raw.Data {
+		values := make(map[string]T, len(sub))
+		for subkey, msg := range sub {
+			var v T
+			subDec := json.NewDecoder(bytes.NewReader(msg))
+			subDec.UseNumber()
+			if err := subDec.Decode(&v); err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("veritas: decode %s.%s: %w", key, subkey, err)
 				}
+				continue
 			}
+			values[subkey] = v
 		}
+		result[key] = values
+	}
+	return result, firstErr
+}
+
+// DataValue returns the single fetched value. Kept for backward
+// compatibility; callers after Context-era error handling should check err.
+func (r *Response) DataValue() (string, error) {
+	if r.Request.responseType != RESPONSETYPE_FETCH_SINGLE || r.Request.valType != VALTYPE_DATA {
+		return "", fmt.Errorf("veritas: can not get data value from non-data response")
+	}
+	return r.StrValue, r.Error
+}
+
+// DataMapValues returns multi-fetch data as strings. Kept as a thin
+// wrapper for backward compatibility; prefer Decode[T] (or GetMultiTyped)
+// for a type other than string. A subkey that fails to decode is left out
+// of the returned map rather than blanking the whole response; the error
+// is recorded on r.Error for callers that want to check it.
+func (r *Response) DataMapValues() map[string]map[string]string {
+	if r.Request.responseType != RESPONSETYPE_FETCH_MULTI || r.Request.valType != VALTYPE_DATA {
+		return make(map[string]map[string]string)
+	}
+	m, err := Decode[string](r)
+	r.Error = err
+	if m == nil {
+		return make(map[string]map[string]string)
 	}
 	return m
 }
 
+// DataCountValues returns multi-fetch counts. Kept as a thin wrapper for
+// backward compatibility; prefer Decode[T] (or GetMultiCountTyped), which
+// decodes straight into int64 instead of round-tripping through float64. A
+// subkey that fails to decode is left out of the returned map rather than
+// blanking the whole response; the error is recorded on r.Error for callers
+// that want to check it.
 func (r *Response) DataCountValues() map[string]map[string]int64 {
 	if r.Request.responseType != RESPONSETYPE_FETCH_MULTI || r.Request.valType != VALTYPE_COUNT {
-		log.Fatal("Can not get data map values from non-data response")
-	}
-	m := make(map[string]map[string]int64)
-	if r.Data["data"] == nil {
-		return m
+		return make(map[string]map[string]int64)
 	}
-	if mi, ok := r.Data["data"].(map[string]interface{}); ok {
-		for k, miv := range mi {
-			if m[k] == nil {
-				m[k] = make(map[string]int64)
-			}
-			if miva, ok := miv.(map[string]interface{}); ok {
-				for sk, sv := range miva {
-					f, fe := strconv.ParseFloat(fmt.Sprintf("%f", sv), 64)
-					if fe != nil {
-						m[k][sk] = 0
-					} else {
-						m[k][sk] = int64(f)
-					}
-				}
-			}
-		}
+	m, err := Decode[int64](r)
+	r.Error = err
+	if m == nil {
+		return make(map[string]map[string]int64)
 	}
 	return m
 }
 
-func (r *Response) CountValue() int64 {
+// CountValue returns the single fetched count. Kept for backward
+// compatibility; callers after Context-era error handling should check err.
+func (r *Response) CountValue() (int64, error) {
 	if r.Request.valType != VALTYPE_COUNT {
-		log.Fatal("Can not get count value from non-count response")
+		return 0, fmt.Errorf("veritas: can not get count value from non-count response")
 	}
-	return r.IntValue
+	return r.IntValue, r.Error
 }
 
 func NewPayloadObjectsKeyValues() *PayloadObjectsKeyValues {
@@ -1,16 +1,126 @@
 package veritas
 
 import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestRequestSignature(t *testing.T) {
-	const expected = "b17f4169e26d7ac3a8457af62c4c8824ad88ef0c49f4eb666c936157405f44a99d1a2ffef0f5e4f5f3a6350d8fba98c720deb0be60600c138d5055fe66f1b72c"
+	const expected = "9d147550d40c417f9c0a83d0dd0b695e226d1151b79fa4f1cf81ffe7e9a8580d019f5879f27a0bb58c36bc4c16528d4e48972b100b8dcfdddbf2c371a56a74d2"
 
 	client := NewClient(1, 1, "test")
+	client.SetClock(func() time.Time { return time.Unix(1700000000, 0) })
+	client.SetNonceSource(bytes.NewReader([]byte{0, 0, 0, 0, 0, 0, 0, 1}))
+
 	signature := client.signRequest("GET", "/asdf", "body-here")
 
 	if signature != expected {
 		t.Errorf("signature expected '%s' but was '%s'", expected, signature)
 	}
 }
+
+func TestBackoffSleepNoBackoffConfigured(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: 0, MaxBackoff: 0}
+
+	if err := backoffSleep(context.Background(), policy, 1); err != nil {
+		t.Errorf("expected nil error for a policy with no backoff configured, got %v", err)
+	}
+}
+
+func TestExecuteContextRetriesOnServerError(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"status":"OK","data":{}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(1, 1, "test")
+	client.SetEndpoint(server.URL)
+	client.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	res, err := client.GetMultiContext(context.Background(), "table", map[string][]string{"k": {"sub"}})
+	if err != nil {
+		t.Fatalf("expected the retry to succeed, got error: %v", err)
+	}
+	if !res.Success {
+		t.Errorf("expected a successful response after retry")
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("expected exactly 2 requests (1 failure + 1 retry), got %d", got)
+	}
+}
+
+func TestExecuteContextReturnsErrorWhenRetriesExhausted(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient(1, 1, "test")
+	client.SetEndpoint(server.URL)
+	client.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	res, err := client.GetMultiContext(context.Background(), "table", map[string][]string{"k": {"sub"}})
+	if err == nil {
+		t.Fatal("expected an error once every attempt returns a retryable status, got nil")
+	}
+	if res != nil {
+		t.Errorf("expected a nil response alongside the error, got %+v", res)
+	}
+	if got := atomic.LoadInt32(&requests); got != 3 {
+		t.Errorf("expected exactly 3 requests (MaxAttempts), got %d", got)
+	}
+}
+
+func TestConcurrentSetRetryPolicyAndExecute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"OK","data":{}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(1, 1, "test")
+	client.SetEndpoint(server.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			client.SetRetryPolicy(&RetryPolicy{MaxAttempts: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+		}()
+		go func() {
+			defer wg.Done()
+			client.GetMultiContext(context.Background(), "table", map[string][]string{"k": {"sub"}})
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDecodePartialFailure(t *testing.T) {
+	resp := &Response{
+		RawBody: `{"status":"OK","data":{"key1":{"good":42,"bad":"not-a-number"}}}`,
+	}
+
+	m, err := Decode[int64](resp)
+	if err == nil {
+		t.Fatal("expected a decode error for the mismatched subkey")
+	}
+	if got, want := m["key1"]["good"], int64(42); got != want {
+		t.Errorf("good subkey = %d, want %d; a bad subkey shouldn't blank out the rest", got, want)
+	}
+	if _, ok := m["key1"]["bad"]; ok {
+		t.Errorf("bad subkey should be omitted from the result, not zero-valued")
+	}
+}